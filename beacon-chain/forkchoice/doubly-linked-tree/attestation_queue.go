@@ -0,0 +1,106 @@
+package doublylinkedtree
+
+import (
+	"context"
+
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+)
+
+// maxAttestationQueueLength bounds the number of future-slot attestations we
+// are willing to buffer. A node that is badly skewed ahead of the network,
+// or under attack, should drop the oldest entries rather than grow without
+// bound.
+const maxAttestationQueueLength = 4096
+
+// queuedAttestation is a single attestation whose target slot was still in
+// the future relative to the store's clock when it arrived.
+type queuedAttestation struct {
+	root           [32]byte
+	validatorIndex primitives.ValidatorIndex
+	targetEpoch    primitives.Epoch
+	targetSlot     primitives.Slot
+}
+
+// InsertAttestation routes a single validator's vote for root into the
+// store. Votes whose targetSlot has not arrived yet relative to currentSlot
+// are buffered in Store.attestationQueue and replayed by
+// processAttestationQueue once the store's clock catches up to them;
+// everything else is applied immediately via the normal vote path. This
+// mirrors process_attestation_queue in the nimbus/lighthouse fork-choice
+// implementations, which Prysm's doubly-linked-tree previously had no
+// equivalent for.
+func (s *Store) InsertAttestation(ctx context.Context, root [32]byte, validatorIndex primitives.ValidatorIndex, targetEpoch primitives.Epoch, targetSlot primitives.Slot, currentSlot primitives.Slot) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if targetSlot > currentSlot {
+		s.queueAttestation(queuedAttestation{
+			root:           root,
+			validatorIndex: validatorIndex,
+			targetEpoch:    targetEpoch,
+			targetSlot:     targetSlot,
+		})
+		attestationQueueDepth.Set(float64(len(s.attestationQueue)))
+		return nil
+	}
+	s.insertVote(root, validatorIndex)
+	return nil
+}
+
+// queueAttestation appends att to the queue, evicting the oldest entry first
+// if the queue is already at capacity.
+func (s *Store) queueAttestation(att queuedAttestation) {
+	if len(s.attestationQueue) >= maxAttestationQueueLength {
+		s.attestationQueue = s.attestationQueue[1:]
+	}
+	s.attestationQueue = append(s.attestationQueue, att)
+}
+
+// processAttestationQueue drains every buffered attestation whose target
+// slot is no longer in the future relative to currentSlot, applying each via
+// the normal vote path. Entries whose target epoch has since fallen behind
+// finalization are dropped without being applied, since they can no longer
+// affect head selection. Callers are expected to invoke this once per tick,
+// after currentSlot has been updated.
+func (s *Store) processAttestationQueue(ctx context.Context, currentSlot primitives.Slot) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if len(s.attestationQueue) == 0 {
+		return nil
+	}
+	finalizedEpoch := primitives.Epoch(0)
+	if s.finalizedCheckpoint != nil {
+		finalizedEpoch = s.finalizedCheckpoint.Epoch
+	}
+
+	remaining := s.attestationQueue[:0]
+	for _, att := range s.attestationQueue {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if att.targetSlot > currentSlot {
+			remaining = append(remaining, att)
+			continue
+		}
+		if att.targetEpoch < finalizedEpoch {
+			continue
+		}
+		s.insertVote(att.root, att.validatorIndex)
+	}
+	s.attestationQueue = remaining
+	attestationQueueDepth.Set(float64(len(s.attestationQueue)))
+	return nil
+}
+
+// insertVote records that validatorIndex's weight should move to root on the
+// next call to ApplyScoreChanges.
+func (s *Store) insertVote(root [32]byte, validatorIndex primitives.ValidatorIndex) {
+	idx := int(validatorIndex)
+	if idx >= len(s.votes) {
+		grown := make([]Vote, idx+1)
+		copy(grown, s.votes)
+		s.votes = grown
+	}
+	s.votes[idx].nextRoot = root
+}
@@ -0,0 +1,77 @@
+package doublylinkedtree
+
+import (
+	"context"
+	"testing"
+
+	forkchoicetypes "github.com/prysmaticlabs/prysm/v3/consensus-types/forkchoice/types"
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore() *Store {
+	return &Store{
+		nodeByRoot:          make(map[[32]byte]*Node),
+		finalizedCheckpoint: &forkchoicetypes.Checkpoint{},
+	}
+}
+
+func TestInsertAttestation_AppliesImmediatelyWhenNotFuture(t *testing.T) {
+	s := newTestStore()
+	var root [32]byte
+	root[0] = 1
+	require.NoError(t, s.InsertAttestation(context.Background(), root, 5, 0, 10, 10))
+	require.Equal(t, 0, len(s.attestationQueue))
+	require.Equal(t, root, s.votes[5].nextRoot)
+}
+
+func TestInsertAttestation_QueuesFutureSlot(t *testing.T) {
+	s := newTestStore()
+	var root [32]byte
+	root[0] = 2
+	require.NoError(t, s.InsertAttestation(context.Background(), root, 3, 0, 20, 10))
+	require.Equal(t, 1, len(s.attestationQueue))
+	require.Equal(t, 0, len(s.votes))
+}
+
+func TestInsertAttestation_QueueEvictsOldestWhenFull(t *testing.T) {
+	s := newTestStore()
+	for i := 0; i < maxAttestationQueueLength; i++ {
+		var root [32]byte
+		root[0] = byte(i % 256)
+		require.NoError(t, s.InsertAttestation(context.Background(), root, primitives.ValidatorIndex(i), 0, 1000, 0))
+	}
+	require.Equal(t, maxAttestationQueueLength, len(s.attestationQueue))
+	first := s.attestationQueue[0].validatorIndex
+
+	var root [32]byte
+	root[0] = 0xff
+	require.NoError(t, s.InsertAttestation(context.Background(), root, primitives.ValidatorIndex(maxAttestationQueueLength), 0, 1000, 0))
+	require.Equal(t, maxAttestationQueueLength, len(s.attestationQueue))
+	require.NotEqual(t, first, s.attestationQueue[0].validatorIndex)
+}
+
+func TestProcessAttestationQueue_DrainsReadyEntries(t *testing.T) {
+	s := newTestStore()
+	var rootA, rootB [32]byte
+	rootA[0] = 1
+	rootB[0] = 2
+	require.NoError(t, s.InsertAttestation(context.Background(), rootA, 0, 0, 5, 0))
+	require.NoError(t, s.InsertAttestation(context.Background(), rootB, 1, 0, 50, 0))
+
+	require.NoError(t, s.processAttestationQueue(context.Background(), 5))
+	require.Equal(t, 1, len(s.attestationQueue))
+	require.Equal(t, rootA, s.votes[0].nextRoot)
+}
+
+func TestProcessAttestationQueue_DropsEntriesBehindFinalization(t *testing.T) {
+	s := newTestStore()
+	s.finalizedCheckpoint = &forkchoicetypes.Checkpoint{Epoch: 10}
+	var root [32]byte
+	root[0] = 1
+	require.NoError(t, s.InsertAttestation(context.Background(), root, 0, 3, 100, 0))
+
+	require.NoError(t, s.processAttestationQueue(context.Background(), 100))
+	require.Equal(t, 0, len(s.attestationQueue))
+	require.Equal(t, 0, len(s.votes))
+}
@@ -0,0 +1,148 @@
+package doublylinkedtree
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/prysmaticlabs/prysm/v3/config/features"
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+)
+
+// Vote is the validator's last seen vote, mirroring the one used by the
+// proto-array implementation: currentRoot is the block root the validator's
+// weight is presently attributed to, nextRoot is the block root it should be
+// moved to on the next call to ApplyScoreChanges.
+type Vote struct {
+	currentRoot [32]byte
+	nextRoot    [32]byte
+}
+
+// ApplyScoreChanges propagates balance changes through the tree using a
+// single reverse pass over Store.nodes instead of the recursive
+// applyWeightChanges/updateBestDescendant pair. oldBalances and newBalances
+// are indexed by validator index and represent the effective balance before
+// and after this call; s.votes[i] carries the block root each validator's
+// weight is currently, and will next be, attributed to.
+//
+// This function must be called with Store.nodesLock held, matching the
+// locking contract of the recursive path it replaces.
+func (s *Store) ApplyScoreChanges(ctx context.Context, oldBalances, newBalances []uint64, justifiedEpoch, finalizedEpoch, currentEpoch primitives.Epoch) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if features.Get().DisableForkChoiceDoublyLinkedTreeDeltaPropagation {
+		if s.treeRootNode == nil {
+			return nil
+		}
+		if err := s.treeRootNode.applyWeightChanges(ctx); err != nil {
+			return err
+		}
+		return s.treeRootNode.updateBestDescendant(ctx, justifiedEpoch, finalizedEpoch, currentEpoch)
+	}
+
+	delta := make([]int64, len(s.nodes))
+	for i := range s.votes {
+		oldBalance, newBalance := uint64(0), uint64(0)
+		if i < len(oldBalances) {
+			oldBalance = oldBalances[i]
+		}
+		if i < len(newBalances) {
+			newBalance = newBalances[i]
+		}
+		vote := s.votes[i]
+		if oldNode, ok := s.nodeByRoot[vote.currentRoot]; ok {
+			delta[oldNode.ix] -= int64(oldBalance)
+		}
+		if newNode, ok := s.nodeByRoot[vote.nextRoot]; ok {
+			delta[newNode.ix] += int64(newBalance)
+		}
+		s.votes[i].currentRoot = vote.nextRoot
+	}
+
+	// balanceDelta is a snapshot of each node's own, unpropagated delta,
+	// taken before the reverse pass below starts folding children's deltas
+	// into their parent's entry of delta. n.balance (the direct weight held
+	// by n itself, as opposed to n.weight which aggregates its subtree)
+	// must only ever move by a node's own delta, never by a descendant's.
+	balanceDelta := make([]int64, len(delta))
+	copy(balanceDelta, delta)
+
+	// A single reverse pass over the flat node slice: children always have a
+	// higher index than their parent, so by the time a node is visited every
+	// child's weight and bestDescendant are already final. This keeps the
+	// stack depth constant regardless of tree depth, unlike the recursive
+	// applyWeightChanges/updateBestDescendant pair it replaces.
+	for idx := len(s.nodes) - 1; idx >= 0; idx-- {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n := s.nodes[idx]
+		n.balance = addDelta(n.balance, balanceDelta[idx])
+		n.weight = addDelta(n.weight, delta[idx])
+		if n.parent != nil {
+			delta[n.parent.ix] += delta[idx]
+		}
+		n.updateBestDescendantFromChildren(justifiedEpoch, finalizedEpoch, currentEpoch)
+	}
+	return nil
+}
+
+// addDelta applies a signed delta to an unsigned weight, floored at zero.
+// Floating below zero would indicate a bookkeeping bug upstream, but we
+// clamp defensively rather than panicking on a quantity that only feeds
+// head selection.
+func addDelta(weight uint64, delta int64) uint64 {
+	if delta >= 0 {
+		return weight + uint64(delta)
+	}
+	d := uint64(-delta)
+	if d > weight {
+		return 0
+	}
+	return weight - d
+}
+
+// updateBestDescendantFromChildren recomputes n.bestDescendant from its
+// children's already-finalized weight and bestDescendant fields. Unlike
+// updateBestDescendant it does not recurse: it assumes the caller has
+// already visited every child, which ApplyScoreChanges guarantees by
+// walking Store.nodes in reverse insertion order.
+func (n *Node) updateBestDescendantFromChildren(justifiedEpoch, finalizedEpoch, currentEpoch primitives.Epoch) {
+	if len(n.children) == 0 {
+		n.bestDescendant = nil
+		return
+	}
+
+	var bestChild *Node
+	bestWeight := uint64(0)
+	hasViableDescendant := false
+	for _, child := range n.children {
+		if child == nil {
+			continue
+		}
+		childLeadsToViableHead := child.leadsToViableHead(justifiedEpoch, currentEpoch)
+		if childLeadsToViableHead && !hasViableDescendant {
+			bestWeight = child.weight
+			bestChild = child
+			hasViableDescendant = true
+		} else if childLeadsToViableHead {
+			if child.weight == bestWeight {
+				if bytes.Compare(child.root[:], bestChild.root[:]) > 0 {
+					bestChild = child
+				}
+			} else if child.weight > bestWeight {
+				bestChild = child
+				bestWeight = child.weight
+			}
+		}
+	}
+	if !hasViableDescendant {
+		n.bestDescendant = nil
+		return
+	}
+	if bestChild.bestDescendant == nil {
+		n.bestDescendant = bestChild
+	} else {
+		n.bestDescendant = bestChild.bestDescendant
+	}
+}
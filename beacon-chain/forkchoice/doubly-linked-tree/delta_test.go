@@ -0,0 +1,200 @@
+package doublylinkedtree
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/v3/config/features"
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+	"github.com/stretchr/testify/require"
+)
+
+// buildRandomTree creates a Store with n nodes: node 0 is the root, and every
+// subsequent node is attached under a uniformly random earlier node. Each
+// node is given a random initial balance via a same-sized validator set,
+// where validator i always votes for node i.
+func buildRandomTree(rnd *rand.Rand, n int) *Store {
+	s := &Store{
+		nodeByRoot: make(map[[32]byte]*Node, n),
+		votes:      make([]Vote, n),
+	}
+	nodes := make([]*Node, n)
+	for i := 0; i < n; i++ {
+		var root [32]byte
+		binary.LittleEndian.PutUint64(root[:8], uint64(i)+1)
+		n := &Node{root: root, balance: uint64(rnd.Intn(100))}
+		if i > 0 {
+			parent := nodes[rnd.Intn(i)]
+			n.parent = parent
+			parent.children = append(parent.children, n)
+		}
+		s.registerNode(n)
+		s.nodeByRoot[root] = n
+		nodes[i] = n
+		s.votes[i] = Vote{currentRoot: root, nextRoot: root}
+	}
+	s.treeRootNode = nodes[0]
+	return s
+}
+
+func balancesFromNodes(s *Store) []uint64 {
+	balances := make([]uint64, len(s.nodes))
+	for i, n := range s.nodes {
+		balances[i] = n.balance
+	}
+	return balances
+}
+
+// FuzzApplyScoreChangesMatchesRecursive checks that the delta-propagation
+// path produces the same weights and best descendants as the recursive
+// applyWeightChanges/updateBestDescendant pair it replaces, for randomly
+// generated trees and balance changes.
+func FuzzApplyScoreChangesMatchesRecursive(f *testing.F) {
+	f.Add(int64(1), 8, 3)
+	f.Add(int64(42), 200, 50)
+	f.Fuzz(func(t *testing.T, seed int64, size int, changes int) {
+		if size < 1 || size > 2000 || changes < 0 || changes > size {
+			t.Skip()
+		}
+		rnd := rand.New(rand.NewSource(seed))
+		s := buildRandomTree(rnd, size)
+		oldBalances := balancesFromNodes(s)
+		newBalances := append([]uint64{}, oldBalances...)
+		for i := 0; i < changes; i++ {
+			idx := rnd.Intn(size)
+			newBalances[idx] = uint64(rnd.Intn(100))
+		}
+
+		const justifiedEpoch, finalizedEpoch, currentEpoch = primitives.Epoch(0), primitives.Epoch(0), primitives.Epoch(0)
+
+		// Recursive reference path: apply the new balances directly to the
+		// nodes, then let applyWeightChanges recompute weight from scratch.
+		for i, n := range s.nodes {
+			n.balance = newBalances[i]
+			n.weight = 0
+		}
+		if err := s.treeRootNode.applyWeightChanges(context.Background()); err != nil {
+			t.Fatalf("applyWeightChanges: %v", err)
+		}
+		if err := s.treeRootNode.updateBestDescendant(context.Background(), justifiedEpoch, finalizedEpoch, currentEpoch); err != nil {
+			t.Fatalf("updateBestDescendant: %v", err)
+		}
+		wantWeight := make(map[[32]byte]uint64, len(s.nodes))
+		wantBest := make(map[[32]byte][32]byte, len(s.nodes))
+		for _, n := range s.nodes {
+			wantWeight[n.root] = n.weight
+			if n.bestDescendant != nil {
+				wantBest[n.root] = n.bestDescendant.root
+			}
+		}
+
+		// Reset and recompute via delta propagation from the same starting
+		// balances, with each node's weight already settled for them.
+		got := buildRandomTreeFromTopology(s)
+		for i, n := range got.nodes {
+			n.balance = oldBalances[i]
+		}
+		if err := got.treeRootNode.applyWeightChanges(context.Background()); err != nil {
+			t.Fatalf("applyWeightChanges: %v", err)
+		}
+		features.Init(&features.Flags{})
+		if err := got.ApplyScoreChanges(context.Background(), oldBalances, newBalances, justifiedEpoch, finalizedEpoch, currentEpoch); err != nil {
+			t.Fatalf("ApplyScoreChanges: %v", err)
+		}
+		for _, n := range got.nodes {
+			if n.weight != wantWeight[n.root] {
+				t.Fatalf("weight mismatch for %x: got %d want %d", n.root, n.weight, wantWeight[n.root])
+			}
+			gotBest := [32]byte{}
+			if n.bestDescendant != nil {
+				gotBest = n.bestDescendant.root
+			}
+			if gotBest != wantBest[n.root] {
+				t.Fatalf("best descendant mismatch for %x: got %x want %x", n.root, gotBest, wantBest[n.root])
+			}
+		}
+	})
+}
+
+// buildRandomTreeFromTopology returns a fresh Store with the exact same
+// shape as src (same parent/child links and roots in the same insertion
+// order) so the delta-propagation path can be exercised independently of the
+// recursive path's in-place mutations.
+func buildRandomTreeFromTopology(src *Store) *Store {
+	s := &Store{
+		nodeByRoot: make(map[[32]byte]*Node, len(src.nodes)),
+		votes:      append([]Vote{}, src.votes...),
+	}
+	clones := make(map[*Node]*Node, len(src.nodes))
+	for _, n := range src.nodes {
+		clone := &Node{root: n.root}
+		clones[n] = clone
+	}
+	for _, n := range src.nodes {
+		clone := clones[n]
+		if n.parent != nil {
+			clone.parent = clones[n.parent]
+			clone.parent.children = append(clone.parent.children, clone)
+		}
+		s.registerNode(clone)
+		s.nodeByRoot[clone.root] = clone
+	}
+	s.treeRootNode = clones[src.treeRootNode]
+	return s
+}
+
+// BenchmarkApplyScoreChanges50kNodes measures the cost of propagating a
+// balance change through a 50,000-node tree, the scale at which the
+// recursive path's stack growth and O(tree) cost become a problem.
+func BenchmarkApplyScoreChanges50kNodes(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	s := buildRandomTree(rnd, 50000)
+	oldBalances := balancesFromNodes(s)
+	newBalances := append([]uint64{}, oldBalances...)
+	for i := 0; i < 1000; i++ {
+		newBalances[rnd.Intn(len(newBalances))] = uint64(rnd.Intn(100))
+	}
+	features.Init(&features.Flags{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.ApplyScoreChanges(context.Background(), oldBalances, newBalances, 0, 0, 0); err != nil {
+			b.Fatalf("ApplyScoreChanges: %v", err)
+		}
+		oldBalances, newBalances = newBalances, oldBalances
+	}
+}
+
+// TestApplyScoreChanges_MovesWeightAcrossDivergentBranches exercises the
+// actual point of delta propagation: a validator's vote moving from one
+// node to a different node on a sibling branch, rather than its balance
+// merely changing in place. It asserts the old branch loses the weight, the
+// new branch gains it, and the common ancestor's aggregate weight (and thus
+// balance bookkeeping) stays consistent.
+func TestApplyScoreChanges_MovesWeightAcrossDivergentBranches(t *testing.T) {
+	features.Init(&features.Flags{})
+	s := &Store{nodeByRoot: make(map[[32]byte]*Node), votes: make([]Vote, 1)}
+	root := linkNode(s, nil, 0, 0)
+	branchA := linkNode(s, root, 1, 1)
+	branchB := linkNode(s, root, 2, 2)
+
+	// Validator 0 starts out voting for branchA with a balance of 100; seed
+	// the steady-state weights that would already be in place as a result.
+	branchA.balance, branchA.weight = 100, 100
+	root.weight = 100
+	s.votes[0] = Vote{currentRoot: branchA.root, nextRoot: branchA.root}
+
+	// The validator's balance is unchanged, but its vote now points at
+	// branchB instead of branchA.
+	s.votes[0].nextRoot = branchB.root
+	require.NoError(t, s.ApplyScoreChanges(context.Background(), []uint64{100}, []uint64{100}, 0, 0, 0))
+
+	require.Equal(t, uint64(0), branchA.balance)
+	require.Equal(t, uint64(0), branchA.weight)
+	require.Equal(t, uint64(100), branchB.balance)
+	require.Equal(t, uint64(100), branchB.weight)
+	require.Equal(t, uint64(100), root.weight)
+	require.Equal(t, branchB.root, s.votes[0].currentRoot)
+}
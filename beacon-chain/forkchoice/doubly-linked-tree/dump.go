@@ -0,0 +1,45 @@
+package doublylinkedtree
+
+import (
+	"context"
+
+	v1 "github.com/prysmaticlabs/prysm/v3/proto/eth/v1"
+)
+
+// ForkChoiceDump returns a full debug snapshot of the store: every node
+// known to the tree plus the justified, finalized, and best-justified
+// checkpoints, so debug tooling can see why a head was, or wasn't, switched.
+func (s *Store) ForkChoiceDump(ctx context.Context) (*v1.ForkChoiceDump, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	s.nodesLock.RLock()
+	defer s.nodesLock.RUnlock()
+
+	var nodes []*v1.ForkChoiceNode
+	if s.treeRootNode != nil {
+		var err error
+		nodes, err = s.treeRootNode.nodeTreeDump(ctx, nodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dump := &v1.ForkChoiceDump{
+		ForkChoiceNodes:         nodes,
+		BestJustifiedCheckpoint: s.bestJustifiedCheckpointProto(),
+	}
+	if s.justifiedCheckpoint != nil {
+		dump.JustifiedCheckpoint = &v1.Checkpoint{
+			Epoch: s.justifiedCheckpoint.Epoch,
+			Root:  s.justifiedCheckpoint.Root[:],
+		}
+	}
+	if s.finalizedCheckpoint != nil {
+		dump.FinalizedCheckpoint = &v1.Checkpoint{
+			Epoch: s.finalizedCheckpoint.Epoch,
+			Root:  s.finalizedCheckpoint.Root[:],
+		}
+	}
+	return dump, nil
+}
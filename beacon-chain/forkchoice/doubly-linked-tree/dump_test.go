@@ -0,0 +1,33 @@
+package doublylinkedtree
+
+import (
+	"context"
+	"testing"
+
+	forkchoicetypes "github.com/prysmaticlabs/prysm/v3/consensus-types/forkchoice/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForkChoiceDump_OmitsBestJustifiedWhenNoneStashed(t *testing.T) {
+	s := newTestStore()
+	s.treeRootNode = linkNode(s, nil, 0, 0)
+
+	dump, err := s.ForkChoiceDump(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, len(dump.ForkChoiceNodes))
+	require.Nil(t, dump.BestJustifiedCheckpoint)
+}
+
+func TestForkChoiceDump_IncludesStashedBestJustified(t *testing.T) {
+	s := newTestStore()
+	_ = linkNode(s, nil, 0, 0)
+	var root [32]byte
+	root[0] = 9
+	s.bestJustifiedCheckpoint = &forkchoicetypes.Checkpoint{Epoch: 7, Root: root}
+
+	dump, err := s.ForkChoiceDump(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, dump.BestJustifiedCheckpoint)
+	require.Equal(t, uint64(7), uint64(dump.BestJustifiedCheckpoint.Epoch))
+	require.Equal(t, root[:], dump.BestJustifiedCheckpoint.Root)
+}
@@ -0,0 +1,53 @@
+package doublylinkedtree
+
+import (
+	"context"
+
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+)
+
+// insert registers a new node for the given block in the store: it links the
+// node under its parent (or installs it as the tree root if it has none),
+// indexes it by root and payload hash, and appends it to the flat node
+// slice that ApplyScoreChanges walks. It is idempotent: inserting a root
+// that is already known returns the existing node.
+//
+// This must be called with Store.nodesLock held.
+func (s *Store) insert(ctx context.Context, slot primitives.Slot, root, parentRoot, payloadHash [32]byte, justifiedEpoch, finalizedEpoch primitives.Epoch, timestamp uint64) (*Node, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if n, ok := s.nodeByRoot[root]; ok {
+		return n, nil
+	}
+
+	n := &Node{
+		slot:                     slot,
+		root:                     root,
+		payloadHash:              payloadHash,
+		justifiedEpoch:           justifiedEpoch,
+		unrealizedJustifiedEpoch: justifiedEpoch,
+		finalizedEpoch:           finalizedEpoch,
+		unrealizedFinalizedEpoch: finalizedEpoch,
+		timestamp:                timestamp,
+	}
+
+	if parent, ok := s.nodeByRoot[parentRoot]; ok {
+		n.parent = parent
+		parent.children = append(parent.children, n)
+	} else {
+		s.treeRootNode = n
+	}
+
+	if s.nodeByRoot == nil {
+		s.nodeByRoot = make(map[[32]byte]*Node)
+	}
+	if s.nodeByPayload == nil {
+		s.nodeByPayload = make(map[[32]byte]*Node)
+	}
+	s.nodeByRoot[root] = n
+	s.nodeByPayload[payloadHash] = n
+	s.registerNode(n)
+
+	return n, nil
+}
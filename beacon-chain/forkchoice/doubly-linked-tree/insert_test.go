@@ -0,0 +1,81 @@
+package doublylinkedtree
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/v3/config/features"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsert_RegistersNodeInFlatSlice(t *testing.T) {
+	s := newTestStore()
+	var root, payload [32]byte
+	root[0] = 1
+	payload[0] = 1
+
+	n, err := s.insert(context.Background(), 0, root, [32]byte{}, payload, 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(s.nodes))
+	require.Equal(t, n, s.nodes[0])
+	require.Equal(t, 0, n.ix)
+	require.Equal(t, s.treeRootNode, n)
+}
+
+func TestInsert_LinksUnderParentAndAppendsToFlatSlice(t *testing.T) {
+	s := newTestStore()
+	var rootA, payloadA, rootB, payloadB [32]byte
+	rootA[0] = 1
+	payloadA[0] = 1
+	rootB[0] = 2
+	payloadB[0] = 2
+
+	a, err := s.insert(context.Background(), 0, rootA, [32]byte{}, payloadA, 0, 0, 0)
+	require.NoError(t, err)
+	b, err := s.insert(context.Background(), 1, rootB, rootA, payloadB, 0, 0, 6)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(s.nodes))
+	require.Equal(t, a, b.parent)
+	require.Equal(t, []*Node{b}, a.children)
+	require.Equal(t, 1, b.ix)
+}
+
+func TestInsert_IsIdempotent(t *testing.T) {
+	s := newTestStore()
+	var root, payload [32]byte
+	root[0] = 1
+	payload[0] = 1
+
+	first, err := s.insert(context.Background(), 0, root, [32]byte{}, payload, 0, 0, 0)
+	require.NoError(t, err)
+	second, err := s.insert(context.Background(), 0, root, [32]byte{}, payload, 0, 0, 0)
+	require.NoError(t, err)
+
+	require.Equal(t, first, second)
+	require.Equal(t, 1, len(s.nodes))
+}
+
+// TestInsert_ApplyScoreChangesEndToEnd exercises the production insertion
+// path (rather than the test helpers in delta_test.go, which build the flat
+// slice directly) to confirm ApplyScoreChanges sees nodes registered via
+// Store.insert.
+func TestInsert_ApplyScoreChangesEndToEnd(t *testing.T) {
+	features.Init(&features.Flags{})
+	s := newTestStore()
+	s.votes = make([]Vote, 1)
+	var rootA, payloadA, rootB, payloadB [32]byte
+	rootA[0] = 1
+	payloadA[0] = 1
+	rootB[0] = 2
+	payloadB[0] = 2
+
+	_, err := s.insert(context.Background(), 0, rootA, [32]byte{}, payloadA, 0, 0, 0)
+	require.NoError(t, err)
+	b, err := s.insert(context.Background(), 1, rootB, rootA, payloadB, 0, 0, 6)
+	require.NoError(t, err)
+	s.votes[0] = Vote{currentRoot: rootB, nextRoot: rootB}
+
+	require.NoError(t, s.ApplyScoreChanges(context.Background(), []uint64{0}, []uint64{100}, 0, 0, 0))
+	require.Equal(t, uint64(100), b.weight)
+}
@@ -0,0 +1,106 @@
+package doublylinkedtree
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+)
+
+// ErrInvalidLatestValidHash is returned by SetOptimisticToInvalid when
+// latestValidHash does not match any ancestor of the invalid root, meaning
+// the execution layer reported a hash fork-choice has never heard of.
+var ErrInvalidLatestValidHash = errors.New("latest valid hash is not an ancestor of the invalid root")
+
+// SetOptimisticToInvalid is called when engine_newPayload reports a block as
+// INVALID. It marks root and all of its descendants invalid so that they can
+// never again be returned as head, then walks back up the ancestor chain to
+// latestValidHash, recomputing bestDescendant along the way. It returns the
+// root of the node at latestValidHash, which callers should treat as the new
+// canonical root.
+//
+// If latestValidHash is the zero hash, the execution layer is declining to
+// say how far back the invalidity goes; rather than walking all the way to
+// the tree root, only root's immediate parent is recomputed and returned.
+//
+// justifiedEpoch, finalizedEpoch, and currentEpoch are the store's current
+// view of those values, the same ones callers pass to ApplyScoreChanges and
+// updateBestDescendant; they are threaded through to
+// updateBestDescendantFromChildren so that viableForHead's defensive-pull
+// comparison is evaluated against the store's real epochs rather than each
+// ancestor's own justifiedEpoch field.
+//
+// This function must be called with Store.nodesLock held, matching the
+// locking contract of applyWeightChanges, updateBestDescendant, and
+// ApplyScoreChanges, all of which mutate the same node fields.
+func (s *Store) SetOptimisticToInvalid(ctx context.Context, root, latestValidHash [32]byte, justifiedEpoch, finalizedEpoch, currentEpoch primitives.Epoch) ([32]byte, error) {
+	if ctx.Err() != nil {
+		return [32]byte{}, ctx.Err()
+	}
+	n, ok := s.nodeByRoot[root]
+	if !ok {
+		return [32]byte{}, ErrUnknownNodeRoot
+	}
+
+	// Mark n and every descendant invalid. An explicit stack keeps this
+	// bounded in memory rather than recursion depth, which matters on the
+	// same long optimistic chains that motivated ApplyScoreChanges.
+	stack := []*Node{n}
+	for len(stack) > 0 {
+		if ctx.Err() != nil {
+			return [32]byte{}, ctx.Err()
+		}
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		cur.invalid = true
+		stack = append(stack, cur.children...)
+	}
+
+	var zeroHash [32]byte
+	if latestValidHash == zeroHash {
+		// The execution layer omits latestValidHash when it has no opinion
+		// on how far back the invalidity goes (e.g. the very first payload
+		// in the chain came back INVALID). Treating the zero hash as "walk
+		// to the tree root" would silently roll back to genesis on every
+		// such response; instead only n's immediate parent can have had its
+		// bestDescendant change, so that's the only node we touch.
+		if n.parent == nil {
+			return n.root, nil
+		}
+		n.parent.updateBestDescendantFromChildren(justifiedEpoch, finalizedEpoch, currentEpoch)
+		return n.parent.root, nil
+	}
+
+	newCanonical := root
+	reachedLatestValidHash := false
+	for p := n.parent; p != nil; p = p.parent {
+		if ctx.Err() != nil {
+			return [32]byte{}, ctx.Err()
+		}
+		// Only the bestDescendant of nodes on the path back to
+		// latestValidHash can have changed, so this is the only path we
+		// need to recompute.
+		p.updateBestDescendantFromChildren(justifiedEpoch, finalizedEpoch, currentEpoch)
+		newCanonical = p.root
+		if p.payloadHash == latestValidHash {
+			reachedLatestValidHash = true
+			break
+		}
+	}
+	if !reachedLatestValidHash {
+		return [32]byte{}, ErrInvalidLatestValidHash
+	}
+	return newCanonical, nil
+}
+
+// IsInvalid returns true if root is known to the store and was previously
+// marked invalid by SetOptimisticToInvalid. Unknown roots are reported as
+// not invalid so that callers on the fast path do not need to special-case
+// ErrUnknownNodeRoot.
+func (s *Store) IsInvalid(root [32]byte) bool {
+	n, ok := s.nodeByRoot[root]
+	if !ok {
+		return false
+	}
+	return n.invalid
+}
@@ -0,0 +1,155 @@
+package doublylinkedtree
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/v3/config/features"
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+	"github.com/stretchr/testify/require"
+)
+
+// linkNode attaches a freshly created node with the given root and payload
+// hash under parent (nil for the tree root) and registers it with s.
+func linkNode(s *Store, parent *Node, root, payloadHash byte) *Node {
+	var r, p [32]byte
+	r[0] = root
+	p[0] = payloadHash
+	n := &Node{root: r, payloadHash: p, parent: parent}
+	if parent != nil {
+		parent.children = append(parent.children, n)
+	}
+	s.registerNode(n)
+	s.nodeByRoot[r] = n
+	return n
+}
+
+// TestSetOptimisticToInvalid_DescendantOfValidAncestor covers the case where
+// a single block deep in an otherwise valid chain is rejected by the
+// execution layer: only it and its own descendants should be marked
+// invalid, while its ancestors and siblings remain untouched.
+func TestSetOptimisticToInvalid_DescendantOfValidAncestor(t *testing.T) {
+	s := newTestStore()
+	root := linkNode(s, nil, 0, 0)
+	a := linkNode(s, root, 1, 1)
+	b := linkNode(s, a, 2, 2)
+	c := linkNode(s, b, 3, 3) // invalid
+	d := linkNode(s, c, 4, 4) // descendant of invalid
+
+	newCanonical, err := s.SetOptimisticToInvalid(context.Background(), c.root, a.payloadHash, 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, a.root, newCanonical)
+
+	require.False(t, root.invalid)
+	require.False(t, a.invalid)
+	require.False(t, b.invalid)
+	require.True(t, c.invalid)
+	require.True(t, d.invalid)
+	require.True(t, s.IsInvalid(c.root))
+	require.False(t, s.IsInvalid(a.root))
+}
+
+// TestSetOptimisticToInvalid_InvalidAncestorWithValidLookingChildren covers
+// the case where the invalid node itself has other children: those children
+// descend from an invalid node and must be considered invalid too, even
+// though nothing about them individually looks wrong.
+func TestSetOptimisticToInvalid_InvalidAncestorWithValidLookingChildren(t *testing.T) {
+	s := newTestStore()
+	root := linkNode(s, nil, 0, 0)
+	bad := linkNode(s, root, 1, 1) // invalid
+	child1 := linkNode(s, bad, 2, 2)
+	child2 := linkNode(s, bad, 3, 3)
+
+	newCanonical, err := s.SetOptimisticToInvalid(context.Background(), bad.root, root.payloadHash, 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, root.root, newCanonical)
+
+	require.True(t, bad.invalid)
+	require.True(t, child1.invalid)
+	require.True(t, child2.invalid)
+	require.False(t, root.invalid)
+}
+
+func TestSetOptimisticToInvalid_UnknownLatestValidHash(t *testing.T) {
+	s := newTestStore()
+	root := linkNode(s, nil, 0, 0)
+	bad := linkNode(s, root, 1, 1)
+
+	var unknownHash [32]byte
+	unknownHash[0] = 0xff
+	_, err := s.SetOptimisticToInvalid(context.Background(), bad.root, unknownHash, 0, 0, 0)
+	require.ErrorIs(t, err, ErrInvalidLatestValidHash)
+}
+
+// TestSetOptimisticToInvalid_RecomputesBestDescendantWithRealEpochs covers
+// the defensive-pull path of viableForHead, which only agrees that a node
+// is justified when the store's real justifiedEpoch/currentEpoch satisfy
+// justifiedEpoch+1 == currentEpoch. Passing an ancestor's own justifiedEpoch
+// as a stand-in for currentEpoch (as opposed to the store's actual current
+// epoch) makes that comparison vacuous and would wrongly leave the
+// surviving sibling out of the new bestDescendant.
+func TestSetOptimisticToInvalid_RecomputesBestDescendantWithRealEpochs(t *testing.T) {
+	features.Init(&features.Flags{EnableDefensivePull: true})
+	s := newTestStore()
+	root := linkNode(s, nil, 0, 0)
+	bad := linkNode(s, root, 1, 1)
+	good := linkNode(s, root, 2, 2)
+	// Not equal to the store's justifiedEpoch, so good.viableForHead can
+	// only return true via the defensive-pull branch below.
+	good.justifiedEpoch = 1
+	good.unrealizedJustifiedEpoch = 2
+
+	const justifiedEpoch, finalizedEpoch, currentEpoch = primitives.Epoch(2), primitives.Epoch(0), primitives.Epoch(3)
+	newCanonical, err := s.SetOptimisticToInvalid(context.Background(), bad.root, root.payloadHash, justifiedEpoch, finalizedEpoch, currentEpoch)
+	require.NoError(t, err)
+	require.Equal(t, root.root, newCanonical)
+
+	require.True(t, bad.invalid)
+	require.NotNil(t, root.bestDescendant)
+	require.Equal(t, good.root, root.bestDescendant.root)
+}
+
+// TestSetOptimisticToInvalid_ZeroLatestValidHash covers the case where the
+// execution layer's INVALID response carries no latestValidHash at all. The
+// zero hash must not be treated as a payload hash to walk back to: on a
+// multi-generation chain that would otherwise roll newCanonical all the way
+// back to the tree root, which is a far more destructive rollback than the
+// execution layer actually asked for.
+func TestSetOptimisticToInvalid_ZeroLatestValidHash(t *testing.T) {
+	s := newTestStore()
+	root := linkNode(s, nil, 0, 0)
+	a := linkNode(s, root, 1, 1)
+	b := linkNode(s, a, 2, 2)
+	c := linkNode(s, b, 3, 3) // invalid
+
+	var zeroHash [32]byte
+	newCanonical, err := s.SetOptimisticToInvalid(context.Background(), c.root, zeroHash, 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, b.root, newCanonical)
+
+	require.True(t, c.invalid)
+	require.False(t, b.invalid)
+	require.False(t, a.invalid)
+	require.False(t, root.invalid)
+}
+
+// TestSetOptimisticToInvalid_ZeroLatestValidHashAtTreeRoot covers the
+// degenerate case where the invalid node is itself the tree root: there is
+// no parent to recompute, so the node's own root is returned.
+func TestSetOptimisticToInvalid_ZeroLatestValidHashAtTreeRoot(t *testing.T) {
+	s := newTestStore()
+	bad := linkNode(s, nil, 0, 0)
+
+	var zeroHash [32]byte
+	newCanonical, err := s.SetOptimisticToInvalid(context.Background(), bad.root, zeroHash, 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, bad.root, newCanonical)
+	require.True(t, bad.invalid)
+}
+
+func TestIsInvalid_UnknownRoot(t *testing.T) {
+	s := newTestStore()
+	var unknown [32]byte
+	unknown[0] = 0xaa
+	require.False(t, s.IsInvalid(unknown))
+}
@@ -0,0 +1,106 @@
+package doublylinkedtree
+
+import (
+	"context"
+
+	"github.com/prysmaticlabs/prysm/v3/config/features"
+	"github.com/prysmaticlabs/prysm/v3/config/params"
+	forkchoicetypes "github.com/prysmaticlabs/prysm/v3/consensus-types/forkchoice/types"
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+	v1 "github.com/prysmaticlabs/prysm/v3/proto/eth/v1"
+)
+
+// shouldUpdateJustifiedCheckpoint reports whether newJustified may be
+// promoted straight into Store.justifiedCheckpoint, mirroring nimbus'
+// should_update_justified_checkpoint: promotion is always safe within the
+// first SafeSlotsToUpdateJustified slots of an epoch, and otherwise only if
+// newJustified's block descends from the block currently justified. When
+// neither holds, the caller is expected to stash newJustified in
+// Store.bestJustifiedCheckpoint instead, for promotion at the next epoch
+// boundary.
+//
+// This gate only runs when features.Get().EnableBestJustifiedCheckpoint is
+// set; with the flag off, callers should continue to promote unconditionally
+// so mainnet behavior is unchanged until the spec test vectors pass.
+func (s *Store) shouldUpdateJustifiedCheckpoint(ctx context.Context, currentSlot primitives.Slot, newJustified *forkchoicetypes.Checkpoint) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if !features.Get().EnableBestJustifiedCheckpoint {
+		return true, nil
+	}
+	if s.justifiedCheckpoint == nil {
+		return true, nil
+	}
+
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	slotsIntoEpoch := currentSlot % slotsPerEpoch
+	if slotsIntoEpoch < params.BeaconConfig().SafeSlotsToUpdateJustified {
+		return true, nil
+	}
+
+	return s.isAncestor(newJustified.Root, s.justifiedCheckpoint.Root), nil
+}
+
+// isAncestor returns true if ancestorRoot is root itself or one of its
+// ancestors in the tree. Unknown roots are conservatively reported as not
+// related.
+func (s *Store) isAncestor(root, ancestorRoot [32]byte) bool {
+	n, ok := s.nodeByRoot[root]
+	if !ok {
+		return false
+	}
+	for p := n; p != nil; p = p.parent {
+		if p.root == ancestorRoot {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCheckpoints is the justified/best-justified half of the fork choice
+// store's on_tick handling. Call it once per incoming justified checkpoint,
+// before updating Store.justifiedCheckpoint elsewhere: if
+// shouldUpdateJustifiedCheckpoint allows an immediate promotion it is a
+// no-op here, otherwise newJustified is stashed in bestJustifiedCheckpoint
+// for promoteBestJustifiedCheckpoint to pick up at the next epoch boundary.
+func (s *Store) updateCheckpoints(ctx context.Context, currentSlot primitives.Slot, newJustified *forkchoicetypes.Checkpoint) (*forkchoicetypes.Checkpoint, error) {
+	ok, err := s.shouldUpdateJustifiedCheckpoint(ctx, currentSlot, newJustified)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return newJustified, nil
+	}
+	s.bestJustifiedCheckpoint = newJustified
+	return s.justifiedCheckpoint, nil
+}
+
+// promoteBestJustifiedCheckpoint promotes a stashed bestJustifiedCheckpoint
+// into justifiedCheckpoint at an epoch boundary, matching nimbus' on_tick
+// promotion. It is a no-op if nothing was stashed.
+func (s *Store) promoteBestJustifiedCheckpoint(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if s.bestJustifiedCheckpoint == nil {
+		return nil
+	}
+	s.justifiedCheckpoint = s.bestJustifiedCheckpoint
+	s.bestJustifiedCheckpoint = nil
+	return nil
+}
+
+// bestJustifiedCheckpointProto returns the stashed best-justified checkpoint
+// in proto form, or nil if nothing is stashed. Store.ForkChoiceDump attaches
+// this to its response so debug tooling can see why a new justified
+// checkpoint was, or wasn't, promoted.
+func (s *Store) bestJustifiedCheckpointProto() *v1.Checkpoint {
+	if s.bestJustifiedCheckpoint == nil {
+		return nil
+	}
+	return &v1.Checkpoint{
+		Epoch: s.bestJustifiedCheckpoint.Epoch,
+		Root:  s.bestJustifiedCheckpoint.Root[:],
+	}
+}
@@ -0,0 +1,78 @@
+package doublylinkedtree
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/v3/config/features"
+	"github.com/prysmaticlabs/prysm/v3/config/params"
+	forkchoicetypes "github.com/prysmaticlabs/prysm/v3/consensus-types/forkchoice/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldUpdateJustifiedCheckpoint_FlagOff(t *testing.T) {
+	features.Init(&features.Flags{})
+	s := newTestStore()
+	var root [32]byte
+	root[0] = 1
+	s.justifiedCheckpoint = &forkchoicetypes.Checkpoint{Epoch: 1, Root: root}
+
+	ok, err := s.shouldUpdateJustifiedCheckpoint(context.Background(), 100, &forkchoicetypes.Checkpoint{Epoch: 2})
+	require.NoError(t, err)
+	require.Equal(t, true, ok)
+}
+
+func TestShouldUpdateJustifiedCheckpoint_NoPriorCheckpoint(t *testing.T) {
+	features.Init(&features.Flags{EnableBestJustifiedCheckpoint: true})
+	s := newTestStore()
+
+	ok, err := s.shouldUpdateJustifiedCheckpoint(context.Background(), 100, &forkchoicetypes.Checkpoint{Epoch: 2})
+	require.NoError(t, err)
+	require.Equal(t, true, ok)
+}
+
+func TestShouldUpdateJustifiedCheckpoint_DescendantOutsideSafeWindow(t *testing.T) {
+	features.Init(&features.Flags{EnableBestJustifiedCheckpoint: true})
+	s := newTestStore()
+	root := linkNode(s, nil, 0, 0)
+	child := linkNode(s, root, 1, 1)
+	s.justifiedCheckpoint = &forkchoicetypes.Checkpoint{Root: root.root}
+
+	currentSlot := params.BeaconConfig().SlotsPerEpoch*2 + params.BeaconConfig().SafeSlotsToUpdateJustified + 1
+	ok, err := s.shouldUpdateJustifiedCheckpoint(context.Background(), currentSlot, &forkchoicetypes.Checkpoint{Root: child.root})
+	require.NoError(t, err)
+	require.Equal(t, true, ok)
+}
+
+func TestShouldUpdateJustifiedCheckpoint_NonDescendantOutsideSafeWindow(t *testing.T) {
+	features.Init(&features.Flags{EnableBestJustifiedCheckpoint: true})
+	s := newTestStore()
+	root := linkNode(s, nil, 0, 0)
+	_ = linkNode(s, root, 1, 1)
+	unrelated := linkNode(s, nil, 2, 2)
+	s.justifiedCheckpoint = &forkchoicetypes.Checkpoint{Root: root.root}
+
+	currentSlot := params.BeaconConfig().SlotsPerEpoch*2 + params.BeaconConfig().SafeSlotsToUpdateJustified + 1
+	ok, err := s.shouldUpdateJustifiedCheckpoint(context.Background(), currentSlot, &forkchoicetypes.Checkpoint{Root: unrelated.root})
+	require.NoError(t, err)
+	require.Equal(t, false, ok)
+}
+
+func TestUpdateCheckpoints_StashesThenPromotes(t *testing.T) {
+	features.Init(&features.Flags{EnableBestJustifiedCheckpoint: true})
+	s := newTestStore()
+	root := linkNode(s, nil, 0, 0)
+	unrelated := linkNode(s, nil, 2, 2)
+	s.justifiedCheckpoint = &forkchoicetypes.Checkpoint{Root: root.root}
+
+	currentSlot := params.BeaconConfig().SlotsPerEpoch*2 + params.BeaconConfig().SafeSlotsToUpdateJustified + 1
+	newJustified := &forkchoicetypes.Checkpoint{Root: unrelated.root, Epoch: 5}
+	promoted, err := s.updateCheckpoints(context.Background(), currentSlot, newJustified)
+	require.NoError(t, err)
+	require.Equal(t, s.justifiedCheckpoint, promoted)
+	require.Equal(t, newJustified, s.bestJustifiedCheckpoint)
+
+	require.NoError(t, s.promoteBestJustifiedCheckpoint(context.Background()))
+	require.Equal(t, newJustified, s.justifiedCheckpoint)
+	require.Nil(t, s.bestJustifiedCheckpoint)
+}
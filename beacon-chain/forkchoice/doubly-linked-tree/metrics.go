@@ -0,0 +1,11 @@
+package doublylinkedtree
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var attestationQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "doubly_linked_tree_attestation_queue_depth",
+	Help: "Number of future-slot attestations currently buffered by the doubly-linked-tree fork choice store.",
+})
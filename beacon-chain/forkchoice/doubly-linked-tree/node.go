@@ -12,13 +12,25 @@ import (
 	"github.com/prysmaticlabs/prysm/v3/time/slots"
 )
 
-// orphanLateBlockFirstThreshold is the number of seconds after which we
-// consider a block to be late, and thus a candidate to being reorged.
-const orphanLateBlockFirstThreshold = 4
+// orphanLateBlockThresholdSeconds returns the number of seconds into a slot
+// after which we consider a block to be late, and thus a candidate to being
+// reorged. It used to be hardcoded to 4, which assumes a 12-second mainnet
+// slot; it is now ReorgLateBlockFractionNum/Den of SecondsPerSlot, so it
+// scales correctly with SECONDS_PER_SLOT on networks with a different slot
+// duration.
+func orphanLateBlockThresholdSeconds() uint64 {
+	cfg := params.BeaconConfig()
+	return cfg.SecondsPerSlot * cfg.ReorgLateBlockFractionNum / cfg.ReorgLateBlockFractionDen
+}
 
-// processAttestationsThreshold  is the number of seconds after which we
-// process attestations for the current slot
-const processAttestationsThreshold = 10
+// processAttestationsThresholdSeconds returns the number of seconds into a
+// slot after which we process attestations for the current slot. It used to
+// be hardcoded to 10, which assumes a 12-second mainnet slot; it is now
+// ProcessAttestationsFractionNum/Den of SecondsPerSlot.
+func processAttestationsThresholdSeconds() uint64 {
+	cfg := params.BeaconConfig()
+	return cfg.SecondsPerSlot * cfg.ProcessAttestationsFractionNum / cfg.ProcessAttestationsFractionDen
+}
 
 // applyWeightChanges recomputes the weight of the node passed as an argument and all of its descendants,
 // using the current balance stored in each node. This function requires a lock
@@ -98,7 +110,13 @@ func (n *Node) updateBestDescendant(ctx context.Context, justifiedEpoch, finaliz
 // viableForHead returns true if the node is viable to head.
 // Any node with different finalized or justified epoch than
 // the ones in fork choice store should not be viable to head.
+// A node the execution layer has rejected is never viable, regardless of
+// its justification: SetOptimisticToInvalid is the only way to mark a node
+// invalid, and it never reverses.
 func (n *Node) viableForHead(justifiedEpoch, currentEpoch primitives.Epoch) bool {
+	if n.invalid {
+		return false
+	}
 	justified := justifiedEpoch == n.justifiedEpoch || justifiedEpoch == 0
 	if features.Get().EnableDefensivePull && !justified && justifiedEpoch+1 == currentEpoch {
 		if n.unrealizedJustifiedEpoch+1 >= currentEpoch && n.justifiedEpoch+2 >= currentEpoch {
@@ -139,7 +157,7 @@ func (n *Node) setNodeAndParentValidated(ctx context.Context) error {
 // slot will have secs = 3 below.
 func (n *Node) arrivedEarly(genesisTime uint64) (bool, error) {
 	secs, err := slots.SecondsSinceSlotStart(n.slot, genesisTime, n.timestamp)
-	return secs < orphanLateBlockFirstThreshold, err
+	return secs < orphanLateBlockThresholdSeconds(), err
 }
 
 // arrivedAfterOrphanCheck returns whether this block was inserted after the
@@ -149,7 +167,22 @@ func (n *Node) arrivedEarly(genesisTime uint64) (bool, error) {
 // slot will have secs = 10 below.
 func (n *Node) arrivedAfterOrphanCheck(genesisTime uint64) (bool, error) {
 	secs, err := slots.SecondsSinceSlotStart(n.slot, genesisTime, n.timestamp)
-	return secs >= processAttestationsThreshold, err
+	return secs >= processAttestationsThresholdSeconds(), err
+}
+
+// ProposerBoostReorg reports whether n, the current head, arrived late
+// enough in its slot to be a candidate for a proposer-boost reorg: it did
+// not arrive within orphanLateBlockThresholdSeconds of the slot start. This
+// is the single decision point callers should use instead of calling
+// arrivedEarly directly, so that A/B-ing the late-block threshold only
+// requires swapping ReorgLateBlockFractionNum/Den in config, not
+// recompiling.
+func (n *Node) ProposerBoostReorg(genesisTime uint64) (bool, error) {
+	early, err := n.arrivedEarly(genesisTime)
+	if err != nil {
+		return false, err
+	}
+	return !early, nil
 }
 
 // nodeTreeDump appends to the given list all the nodes descending from this one
@@ -175,9 +208,12 @@ func (n *Node) nodeTreeDump(ctx context.Context, nodes []*v1.ForkChoiceNode) ([]
 		ExecutionBlockHash:       n.payloadHash[:],
 		Timestamp:                n.timestamp,
 	}
-	if n.optimistic {
+	switch {
+	case n.invalid:
+		thisNode.Validity = v1.ForkChoiceNodeValidity_INVALID
+	case n.optimistic:
 		thisNode.Validity = v1.ForkChoiceNodeValidity_OPTIMISTIC
-	} else {
+	default:
 		thisNode.Validity = v1.ForkChoiceNodeValidity_VALID
 	}
 
@@ -0,0 +1,66 @@
+package doublylinkedtree
+
+import (
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/v3/config/params"
+	"github.com/stretchr/testify/require"
+)
+
+// setSlotDuration overrides SecondsPerSlot for the duration of the test,
+// restoring the previous config on cleanup.
+func setSlotDuration(t *testing.T, secondsPerSlot uint64) {
+	params.SetupTestConfigCleanup(t)
+	cfg := params.BeaconConfig().Copy()
+	cfg.SecondsPerSlot = secondsPerSlot
+	params.OverrideBeaconConfig(cfg)
+}
+
+func TestOrphanLateBlockThresholdSeconds_ScalesWithSlotDuration(t *testing.T) {
+	tests := []struct {
+		name           string
+		secondsPerSlot uint64
+		want           uint64
+	}{
+		{name: "mainnet 12s slot", secondsPerSlot: 12, want: 4},
+		{name: "6s devnet slot", secondsPerSlot: 6, want: 2},
+		{name: "3s slot", secondsPerSlot: 3, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setSlotDuration(t, tt.secondsPerSlot)
+			require.Equal(t, tt.want, orphanLateBlockThresholdSeconds())
+		})
+	}
+}
+
+func TestProcessAttestationsThresholdSeconds_ScalesWithSlotDuration(t *testing.T) {
+	tests := []struct {
+		name           string
+		secondsPerSlot uint64
+		want           uint64
+	}{
+		{name: "mainnet 12s slot", secondsPerSlot: 12, want: 10},
+		{name: "6s devnet slot", secondsPerSlot: 6, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setSlotDuration(t, tt.secondsPerSlot)
+			require.Equal(t, tt.want, processAttestationsThresholdSeconds())
+		})
+	}
+}
+
+func TestProposerBoostReorg_UsesConfiguredThreshold(t *testing.T) {
+	setSlotDuration(t, 6)
+	n := &Node{slot: 0, timestamp: 0}
+
+	reorg, err := n.ProposerBoostReorg(0)
+	require.NoError(t, err)
+	require.Equal(t, false, reorg)
+
+	late := &Node{slot: 0, timestamp: 3}
+	reorg, err = late.ProposerBoostReorg(0)
+	require.NoError(t, err)
+	require.Equal(t, true, reorg)
+}
@@ -0,0 +1,83 @@
+package doublylinkedtree
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	forkchoicetypes "github.com/prysmaticlabs/prysm/v3/consensus-types/forkchoice/types"
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+)
+
+// ErrNilNode is returned whenever a nil node is passed in when it is not
+// expected.
+var ErrNilNode = errors.New("nil node")
+
+// ErrUnknownNodeRoot is returned when a node root cannot be found in the
+// store.
+var ErrUnknownNodeRoot = errors.New("unknown node root")
+
+// Node defines the individual block that is stored in the fork choice store,
+// including its view of weight and viability for head.
+type Node struct {
+	slot                     primitives.Slot
+	root                     [32]byte
+	payloadHash              [32]byte
+	parent                   *Node
+	children                 []*Node
+	justifiedEpoch           primitives.Epoch
+	unrealizedJustifiedEpoch primitives.Epoch
+	finalizedEpoch           primitives.Epoch
+	unrealizedFinalizedEpoch primitives.Epoch
+	balance                  uint64
+	weight                   uint64
+	bestDescendant           *Node
+	optimistic               bool
+	// invalid is true once the execution layer has reported this node's
+	// payload, or an ancestor's, as INVALID. Set exclusively by
+	// Store.SetOptimisticToInvalid and never reversed.
+	invalid   bool
+	timestamp uint64
+	// ix is the node's position in Store.nodes, the flat slice that mirrors
+	// the tree in insertion order. It is set once when the node is first
+	// registered and never changes afterwards.
+	ix int
+}
+
+// Store defines the fork choice store which includes block nodes and the
+// last view of the checkpoints.
+type Store struct {
+	justifiedCheckpoint *forkchoicetypes.Checkpoint
+	finalizedCheckpoint *forkchoicetypes.Checkpoint
+	// bestJustifiedCheckpoint stashes a justified checkpoint that arrived
+	// outside the safe-slots window and does not descend from
+	// justifiedCheckpoint; it is promoted at the next epoch boundary by
+	// Store.promoteBestJustifiedCheckpoint. See shouldUpdateJustifiedCheckpoint.
+	bestJustifiedCheckpoint *forkchoicetypes.Checkpoint
+	proposerBoostRoot       [32]byte
+	treeRootNode            *Node
+	headNode                *Node
+	nodeByRoot              map[[32]byte]*Node
+	nodeByPayload           map[[32]byte]*Node
+	genesisTime             uint64
+	// nodes is a flat slice of every node currently tracked by the store, in
+	// the order they were inserted. Together with Node.ix this lets
+	// ApplyScoreChanges walk the tree in a single reverse pass instead of
+	// recursing from the root.
+	nodes     []*Node
+	nodesLock sync.RWMutex
+	// votes holds each validator's current and next vote target root, used
+	// by ApplyScoreChanges to compute per-node weight deltas.
+	votes []Vote
+	// attestationQueue buffers attestations whose target slot is still in
+	// the future relative to the store's current tick. See
+	// Store.InsertAttestation and Store.processAttestationQueue.
+	attestationQueue []queuedAttestation
+}
+
+// registerNode appends n to the flat node slice and records its index. This
+// is called once from Store.insert for every newly inserted node, after the
+// node is linked to its parent.
+func (s *Store) registerNode(n *Node) {
+	n.ix = len(s.nodes)
+	s.nodes = append(s.nodes, n)
+}
@@ -0,0 +1,46 @@
+package features
+
+import "sync"
+
+// Flags is a struct to represent which features the client will run with.
+// Only the flags consumed by checked-out callers are declared here; the
+// full set carries many more.
+type Flags struct {
+	// EnableDefensivePull allows viableForHead to treat a node as justified
+	// one epoch early when its unrealized justification already supports it.
+	EnableDefensivePull bool
+
+	// DisableForkChoiceDoublyLinkedTreeDeltaPropagation falls back
+	// Store.ApplyScoreChanges to the recursive
+	// applyWeightChanges/updateBestDescendant pair instead of the
+	// delta-propagation pass, for a grace period while the new path is
+	// rolled out.
+	DisableForkChoiceDoublyLinkedTreeDeltaPropagation bool
+
+	// EnableBestJustifiedCheckpoint gates Store.shouldUpdateJustifiedCheckpoint's
+	// safe-slots/descendancy gate. With it off, a new justified checkpoint
+	// is always promoted immediately, matching mainnet behavior until the
+	// spec test vectors pass.
+	EnableBestJustifiedCheckpoint bool
+}
+
+var featureConfig *Flags
+var featureConfigLock sync.RWMutex
+
+// Get returns the current feature flag configuration. Absent a prior call to
+// Init, it returns the zero-value Flags, i.e. every feature disabled.
+func Get() *Flags {
+	featureConfigLock.RLock()
+	defer featureConfigLock.RUnlock()
+	if featureConfig == nil {
+		return &Flags{}
+	}
+	return featureConfig
+}
+
+// Init sets the global feature flag configuration to c.
+func Init(c *Flags) {
+	featureConfigLock.Lock()
+	defer featureConfigLock.Unlock()
+	featureConfig = c
+}
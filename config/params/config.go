@@ -0,0 +1,68 @@
+package params
+
+import (
+	"github.com/prysmaticlabs/prysm/v3/consensus-types/primitives"
+)
+
+// BeaconChainConfig contains the constant genesis values and configurable
+// network parameters of the beacon chain. Only the fields consumed by
+// checked-out callers are declared here; the full config carries many more.
+type BeaconChainConfig struct {
+	// ZeroHash is the zero-value block root, used to detect the sentinel
+	// fork choice root.
+	ZeroHash [32]byte
+
+	// SecondsPerSlot is SECONDS_PER_SLOT.
+	SecondsPerSlot uint64
+	// SlotsPerEpoch is SLOTS_PER_EPOCH.
+	SlotsPerEpoch primitives.Slot
+	// SafeSlotsToUpdateJustified is the number of slots into an epoch during
+	// which a new justified checkpoint may always be promoted immediately,
+	// regardless of descendancy from the current justified checkpoint.
+	SafeSlotsToUpdateJustified primitives.Slot
+
+	// ReorgLateBlockFractionNum/Den express the late-block/orphan threshold
+	// as a fraction of SecondsPerSlot (Num/Den * SecondsPerSlot seconds),
+	// instead of a value hardcoded for a 12-second mainnet slot.
+	ReorgLateBlockFractionNum uint64
+	ReorgLateBlockFractionDen uint64
+
+	// ProcessAttestationsFractionNum/Den express the threshold, as a
+	// fraction of SecondsPerSlot, after which attestations for the current
+	// slot are processed.
+	ProcessAttestationsFractionNum uint64
+	ProcessAttestationsFractionDen uint64
+}
+
+// Copy returns a full copy of the config object.
+func (c *BeaconChainConfig) Copy() *BeaconChainConfig {
+	config := *c
+	return &config
+}
+
+// mainnetBeaconConfig holds the mainnet values for the fields above:
+// SecondsPerSlot=12, and ReorgLateBlockFractionNum/Den=1/3 (4s) and
+// ProcessAttestationsFractionNum/Den=5/6 (10s) reproduce the thresholds that
+// used to be hardcoded.
+var mainnetBeaconConfig = &BeaconChainConfig{
+	SecondsPerSlot:                 12,
+	SlotsPerEpoch:                  32,
+	SafeSlotsToUpdateJustified:     8,
+	ReorgLateBlockFractionNum:      1,
+	ReorgLateBlockFractionDen:      3,
+	ProcessAttestationsFractionNum: 5,
+	ProcessAttestationsFractionDen: 6,
+}
+
+var beaconConfig = mainnetBeaconConfig
+
+// BeaconConfig returns the current active beacon chain config.
+func BeaconConfig() *BeaconChainConfig {
+	return beaconConfig
+}
+
+// OverrideBeaconConfig overrides the active config with cfg. Tests use this
+// to exercise non-mainnet network parameters, e.g. a shorter slot duration.
+func OverrideBeaconConfig(cfg *BeaconChainConfig) {
+	beaconConfig = cfg
+}
@@ -0,0 +1,13 @@
+package params
+
+import "testing"
+
+// SetupTestConfigCleanup sets up a test to restore the currently active
+// config once it completes, so that an OverrideBeaconConfig call made
+// mid-test doesn't leak into other tests.
+func SetupTestConfigCleanup(t *testing.T) {
+	prev := beaconConfig
+	t.Cleanup(func() {
+		beaconConfig = prev
+	})
+}